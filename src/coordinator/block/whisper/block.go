@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package whisper
+
+import "github.com/m3db/m3db/src/coordinator/block"
+
+// whisperBlock is an already-consolidated, in-memory block.Block
+// materialized from one or more whisper archives. Unlike
+// ts/m3db/block.MultiSeriesBlock, it has no underlying m3db SeriesIterators
+// to drain lazily: every value is decoded and aligned up front.
+type whisperBlock struct {
+	meta   block.Metadata
+	values [][]float64 // one slice per series, len(values[i]) == meta.Bounds.Steps()
+	metas  []block.SeriesMeta
+}
+
+func newBlock(meta block.Metadata, values [][]float64, metas []block.SeriesMeta) *whisperBlock {
+	return &whisperBlock{meta: meta, values: values, metas: metas}
+}
+
+// Meta returns the metadata for the block
+func (b *whisperBlock) Meta() block.Metadata {
+	return b.meta
+}
+
+// StepIter creates a new step iterator for the block
+func (b *whisperBlock) StepIter() (block.StepIter, error) {
+	return &whisperStepIter{block: b, index: -1}, nil
+}
+
+// SeriesIter creates a new series iterator for the block
+func (b *whisperBlock) SeriesIter() (block.SeriesIter, error) {
+	return &whisperSeriesIter{block: b, index: -1}, nil
+}
+
+// Close frees up resources
+func (b *whisperBlock) Close() error {
+	return nil
+}
+
+type whisperStepIter struct {
+	block *whisperBlock
+	index int
+}
+
+// Meta returns the metadata for the step iter
+func (it *whisperStepIter) Meta() block.Metadata {
+	return it.block.meta
+}
+
+// SeriesMeta returns metadata for the individual timeseries
+func (it *whisperStepIter) SeriesMeta() []block.SeriesMeta {
+	return it.block.metas
+}
+
+// StepCount returns the total steps/columns
+func (it *whisperStepIter) StepCount() int {
+	return it.block.meta.Bounds.Steps()
+}
+
+// Next moves to the next item
+func (it *whisperStepIter) Next() bool {
+	it.index++
+	return it.index < it.block.meta.Bounds.Steps()
+}
+
+// Current returns the slice of vals and timestamps for that step
+func (it *whisperStepIter) Current() (block.Step, error) {
+	t, err := it.block.meta.Bounds.TimeForIndex(it.index)
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]float64, len(it.block.values))
+	for i, series := range it.block.values {
+		vals[i] = series[it.index]
+	}
+	return block.NewColStep(t, vals), nil
+}
+
+// Close frees up resources
+func (it *whisperStepIter) Close() {}
+
+type whisperSeriesIter struct {
+	block *whisperBlock
+	index int
+}
+
+// Meta returns the metadata for the series iter
+func (it *whisperSeriesIter) Meta() block.Metadata {
+	return it.block.meta
+}
+
+// SeriesMeta returns metadata for the individual timeseries
+func (it *whisperSeriesIter) SeriesMeta() []block.SeriesMeta {
+	return it.block.metas
+}
+
+// SeriesCount returns the total number of series
+func (it *whisperSeriesIter) SeriesCount() int {
+	return len(it.block.values)
+}
+
+// Next moves to the next series
+func (it *whisperSeriesIter) Next() bool {
+	it.index++
+	return it.index < len(it.block.values)
+}
+
+// Current returns the values and metadata for the current series
+func (it *whisperSeriesIter) Current() (block.Series, error) {
+	return block.NewSeries(it.block.values[it.index], it.block.metas[it.index]), nil
+}
+
+// Close frees up resources
+func (it *whisperSeriesIter) Close() {}
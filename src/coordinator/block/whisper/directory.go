@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package whisper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/m3db/m3db/src/coordinator/block"
+	"github.com/m3db/m3db/src/coordinator/models"
+)
+
+// TagExtractor derives additional tags for a series from its graphite
+// dot-path (e.g. "stats.foo.bar"), on top of the __name__ tag that
+// NewWhisperDirectoryBlockReader always sets from the full path.
+type TagExtractor func(dotPath string) models.Tags
+
+// NewWhisperDirectoryBlockReader walks dir for *.wsp files and materializes
+// all of them into a single block.Block over bounds. Each file's tags are
+// seeded with {__name__: <dot-path>}, where dot-path is derived from the
+// file's location relative to dir (e.g. stats/foo/bar.wsp becomes
+// stats.foo.bar), then extended by every extractor in extractors.
+func NewWhisperDirectoryBlockReader(dir string, bounds block.Bounds, extractors ...TagExtractor) (block.Block, error) {
+	var (
+		values [][]float64
+		metas  []block.SeriesMeta
+	)
+
+	walkErr := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".wsp" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		dotPath := dotPathFromRel(rel)
+
+		tags := models.Tags{models.MetricName: dotPath}
+		for _, extract := range extractors {
+			for k, v := range extract(dotPath) {
+				tags[k] = v
+			}
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		b, err := newWhisperBlock(f, bounds, tags)
+		if err != nil {
+			return fmt.Errorf("whisper: materializing %s: %v", path, err)
+		}
+
+		values = append(values, b.values...)
+		metas = append(metas, b.metas...)
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+
+	return newBlock(block.Metadata{Bounds: bounds}, values, metas), nil
+}
+
+// dotPathFromRel converts a whisper file's path relative to the scanned
+// root (e.g. "stats/foo/bar.wsp") into its graphite dot-path
+// ("stats.foo.bar").
+func dotPathFromRel(rel string) string {
+	rel = strings.TrimSuffix(rel, filepath.Ext(rel))
+	return strings.ReplaceAll(filepath.ToSlash(rel), "/", ".")
+}
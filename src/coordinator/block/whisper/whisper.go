@@ -0,0 +1,266 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package whisper reads Graphite Whisper (.wsp) archive files and
+// materializes them as coordinator blocks, so that a deployment migrating
+// off graphite/carbon can query and backfill historical data through the
+// same path that already vends m3db-backed blocks.
+package whisper
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/m3db/m3db/src/coordinator/block"
+	"github.com/m3db/m3db/src/coordinator/models"
+)
+
+const (
+	metadataHeaderSize    = 16
+	archiveInfoHeaderSize = 12
+	pointSize             = 12
+)
+
+// AggregationMethod is a whisper archive's aggregation method, used to
+// consolidate points when an archive's native resolution is finer than the
+// requested block step.
+type AggregationMethod uint32
+
+// Aggregation methods supported by the whisper file format.
+const (
+	AggregationAverage AggregationMethod = iota + 1
+	AggregationSum
+	AggregationLast
+	AggregationMax
+	AggregationMin
+)
+
+// header is the fixed whisper file header.
+type header struct {
+	aggregation  AggregationMethod
+	maxRetention uint32
+	xFilesFactor float32
+	archives     []archiveInfo
+}
+
+// archiveInfo describes a single retention archive within a whisper file.
+type archiveInfo struct {
+	offset          uint32
+	secondsPerPoint uint32
+	points          uint32
+}
+
+// point is a single (timestamp, value) slot within an archive's ring buffer.
+// A slot that has never been written has a zero timestamp.
+type point struct {
+	timestamp uint32
+	value     float64
+}
+
+// NewWhisperBlockReader reads the whisper file at path and materializes a
+// block.Block covering bounds, tagged with tags. The archive whose
+// resolution best matches bounds is selected, points outside
+// [bounds.Start, bounds.End) are discarded, and the remainder is aligned
+// onto the block's step grid, consolidating with the archive's aggregation
+// method where more than one point falls in the same step.
+func NewWhisperBlockReader(path string, bounds block.Bounds, tags models.Tags) (block.Block, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return newWhisperBlock(f, bounds, tags)
+}
+
+func newWhisperBlock(r io.ReaderAt, bounds block.Bounds, tags models.Tags) (*whisperBlock, error) {
+	hdr, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := pickArchive(hdr.archives, bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := readArchive(r, archive)
+	if err != nil {
+		return nil, err
+	}
+
+	values := alignToBounds(points, bounds, hdr.aggregation)
+	meta := block.SeriesMeta{
+		Name: tags[models.MetricName],
+		Tags: tags,
+	}
+
+	return newBlock(block.Metadata{Bounds: bounds, Tags: tags}, [][]float64{values}, []block.SeriesMeta{meta}), nil
+}
+
+func readHeader(r io.ReaderAt) (header, error) {
+	buf := make([]byte, metadataHeaderSize)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return header{}, fmt.Errorf("whisper: reading header: %v", err)
+	}
+
+	hdr := header{
+		aggregation:  AggregationMethod(binary.BigEndian.Uint32(buf[0:4])),
+		maxRetention: binary.BigEndian.Uint32(buf[4:8]),
+		xFilesFactor: math.Float32frombits(binary.BigEndian.Uint32(buf[8:12])),
+	}
+
+	archiveCount := binary.BigEndian.Uint32(buf[12:16])
+	hdr.archives = make([]archiveInfo, archiveCount)
+
+	infoBuf := make([]byte, archiveInfoHeaderSize)
+	for i := uint32(0); i < archiveCount; i++ {
+		off := int64(metadataHeaderSize) + int64(i)*archiveInfoHeaderSize
+		if _, err := r.ReadAt(infoBuf, off); err != nil {
+			return header{}, fmt.Errorf("whisper: reading archive %d info: %v", i, err)
+		}
+		hdr.archives[i] = archiveInfo{
+			offset:          binary.BigEndian.Uint32(infoBuf[0:4]),
+			secondsPerPoint: binary.BigEndian.Uint32(infoBuf[4:8]),
+			points:          binary.BigEndian.Uint32(infoBuf[8:12]),
+		}
+	}
+
+	return hdr, nil
+}
+
+// pickArchive selects the coarsest archive whose resolution is still at
+// least as fine as bounds.StepSize, falling back to the finest archive
+// available if none are coarse enough.
+func pickArchive(archives []archiveInfo, bounds block.Bounds) (archiveInfo, error) {
+	if len(archives) == 0 {
+		return archiveInfo{}, errors.New("whisper: file has no archives")
+	}
+
+	step := uint32(bounds.StepSize.Seconds())
+	best := archives[0]
+	for _, a := range archives {
+		if a.secondsPerPoint <= step && a.secondsPerPoint > best.secondsPerPoint {
+			best = a
+		}
+	}
+	return best, nil
+}
+
+func readArchive(r io.ReaderAt, archive archiveInfo) ([]point, error) {
+	buf := make([]byte, int(archive.points)*pointSize)
+	if _, err := r.ReadAt(buf, int64(archive.offset)); err != nil {
+		return nil, fmt.Errorf("whisper: reading archive ring: %v", err)
+	}
+
+	points := make([]point, archive.points)
+	for i := range points {
+		slot := buf[i*pointSize : (i+1)*pointSize]
+		points[i] = point{
+			timestamp: binary.BigEndian.Uint32(slot[0:4]),
+			value:     math.Float64frombits(binary.BigEndian.Uint64(slot[4:12])),
+		}
+	}
+	return points, nil
+}
+
+// alignToBounds walks an archive's decoded ring, discards points outside
+// [bounds.Start, bounds.End), and consolidates the remainder onto bounds'
+// step grid with method when more than one point falls in the same step.
+// Steps with no points are left as NaN.
+func alignToBounds(points []point, bounds block.Bounds, method AggregationMethod) []float64 {
+	steps := bounds.Steps()
+	values := make([]float64, steps)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+
+	if len(points) == 0 {
+		return values
+	}
+
+	// NB: archives are ring buffers, so slot 0 is not guaranteed to be the
+	// first-written slot; an unwritten slot 0 doesn't mean the whole archive
+	// is empty. The per-point loop below skips unwritten (timestamp == 0)
+	// slots wherever they fall.
+	start := uint32(bounds.Start.Unix())
+	end := uint32(bounds.End.Unix())
+	stepSize := uint32(bounds.StepSize.Seconds())
+
+	buckets := make([][]float64, steps)
+	for _, p := range points {
+		if p.timestamp == 0 || p.timestamp < start || p.timestamp >= end {
+			continue
+		}
+
+		idx := int((p.timestamp - start) / stepSize)
+		if idx < 0 || idx >= steps {
+			continue
+		}
+		buckets[idx] = append(buckets[idx], p.value)
+	}
+
+	for i, vals := range buckets {
+		if len(vals) > 0 {
+			values[i] = consolidate(vals, method)
+		}
+	}
+
+	return values
+}
+
+func consolidate(vals []float64, method AggregationMethod) float64 {
+	switch method {
+	case AggregationSum:
+		var sum float64
+		for _, v := range vals {
+			sum += v
+		}
+		return sum
+	case AggregationLast:
+		return vals[len(vals)-1]
+	case AggregationMax:
+		max := vals[0]
+		for _, v := range vals[1:] {
+			if v > max {
+				max = v
+			}
+		}
+		return max
+	case AggregationMin:
+		min := vals[0]
+		for _, v := range vals[1:] {
+			if v < min {
+				min = v
+			}
+		}
+		return min
+	default: // AggregationAverage
+		var sum float64
+		for _, v := range vals {
+			sum += v
+		}
+		return sum / float64(len(vals))
+	}
+}
@@ -21,12 +21,35 @@
 package block
 
 import (
-	"errors"
+	"math"
+	"sync"
 
 	"github.com/m3db/m3db/src/coordinator/block"
 	"github.com/m3db/m3db/src/coordinator/models"
 )
 
+// valuesPool recycles the []float64 slabs used to materialize a series'
+// values out of its underlying m3db iterators. It is shared by StepIter and
+// SeriesIter so that long running coordinator queries don't grow the heap
+// on every step/series they consume.
+var valuesPool = sync.Pool{
+	New: func() interface{} {
+		return make([]float64, 0)
+	},
+}
+
+func acquireValues(n int) []float64 {
+	vals := valuesPool.Get().([]float64)
+	if cap(vals) < n {
+		return make([]float64, n)
+	}
+	return vals[:n]
+}
+
+func releaseValues(vals []float64) {
+	valuesPool.Put(vals[:0])
+}
+
 // MultiSeriesBlock represents a vertically oriented block
 type MultiSeriesBlock struct {
 	Blocks   ConsolidatedSeriesBlocks
@@ -42,6 +65,7 @@ type multiSeriesBlockStepIter struct {
 	index       int
 	meta        block.Metadata
 	blocks      ConsolidatedSeriesBlocks
+	vals        []float64
 }
 
 // StepIter creates a new step iterator for a given MultiSeriesBlock
@@ -56,14 +80,23 @@ func (m MultiSeriesBlock) StepIter() (block.StepIter, error) {
 
 // SeriesIter creates a new series iterator for a given MultiSeriesBlock
 func (m MultiSeriesBlock) SeriesIter() (block.SeriesIter, error) {
-	// todo(braskin): implement SeriesIter()
-	return nil, errors.New("SeriesIter not implemented")
+	return &multiSeriesBlockSeriesIter{
+		seriesIters: newConsolidatedSeriesBlockIters(m.Blocks),
+		index:       -1,
+		meta:        m.Metadata,
+		blocks:      m.Blocks,
+	}, nil
 }
 
-// Close frees up resources
+// Close frees up resources by closing the underlying m3db SeriesIterators
+// for every namespace block across all series.
 func (m MultiSeriesBlock) Close() error {
-	// todo(braskin): Actually free up resources
-	return errors.New("Close not implemented")
+	for _, seriesBlock := range m.Blocks {
+		for _, nsBlock := range seriesBlock.ConsolidatedNSBlocks {
+			nsBlock.SeriesIterators.Close()
+		}
+	}
+	return nil
 }
 
 func newConsolidatedSeriesBlockIters(blocks ConsolidatedSeriesBlocks) []block.ValueIterator {
@@ -135,7 +168,10 @@ func (m *multiSeriesBlockStepIter) Next() bool {
 	return true
 }
 
-// Current returns the slice of vals and timestamps for that step
+// Current returns the slice of vals and timestamps for that step.
+//
+// NB: the returned Step's values slice is reused in place on the next call
+// to Next(), matching the existing m3db iterator Current() convention.
 func (m *multiSeriesBlockStepIter) Current() (block.Step, error) {
 	bounds := m.meta.Bounds
 	t, err := bounds.TimeForIndex(m.index)
@@ -143,13 +179,104 @@ func (m *multiSeriesBlockStepIter) Current() (block.Step, error) {
 		return nil, err
 	}
 
-	values := make([]float64, len(m.seriesIters))
+	if m.vals == nil {
+		m.vals = acquireValues(len(m.seriesIters))
+	}
 	for i, s := range m.seriesIters {
-		values[i] = s.Current()
+		m.vals[i] = s.Current()
+	}
+
+	return block.NewColStep(t, m.vals), nil
+}
+
+// Close returns the step iter's value slab to the pool. The underlying m3db
+// SeriesIterators are owned by the MultiSeriesBlock they were created from,
+// not by this iterator, and are closed by MultiSeriesBlock.Close instead;
+// closing them here too would double-close a pool-backed resource shared
+// with any other iterator still reading the same block.
+func (m *multiSeriesBlockStepIter) Close() {
+	if m.vals != nil {
+		releaseValues(m.vals)
+		m.vals = nil
+	}
+}
+
+type multiSeriesBlockSeriesIter struct {
+	seriesIters []block.ValueIterator
+	index       int
+	meta        block.Metadata
+	blocks      ConsolidatedSeriesBlocks
+	vals        []float64
+}
+
+// Meta returns the metadata for the series iter
+func (m *multiSeriesBlockSeriesIter) Meta() block.Metadata {
+	return m.meta
+}
+
+// SeriesMeta returns metadata for the individual timeseries
+func (m *multiSeriesBlockSeriesIter) SeriesMeta() []block.SeriesMeta {
+	metas := make([]block.SeriesMeta, len(m.blocks))
+	for i, s := range m.blocks {
+		metas[i].Name = s.Metadata.Tags[models.MetricName]
+		metas[i].Tags = s.Metadata.Tags
 	}
+	return metas
+}
 
-	return block.NewColStep(t, values), nil
+// SeriesCount returns the total number of series
+func (m *multiSeriesBlockSeriesIter) SeriesCount() int {
+	return len(m.blocks)
 }
 
-// TODO: Actually free up resources
-func (m *multiSeriesBlockStepIter) Close() {}
+// Next moves to the next series
+func (m *multiSeriesBlockSeriesIter) Next() bool {
+	m.index++
+	return m.index < len(m.seriesIters)
+}
+
+// Current drains the current series' ValueIterator across its full step
+// range into a reusable buffer, filling NaN for any step the underlying
+// m3db SeriesIterator didn't produce a value for.
+//
+// NB: the returned Series' Values slice is only valid until the next call
+// to Next(), matching the existing m3db iterator Current() convention.
+func (m *multiSeriesBlockSeriesIter) Current() (block.Series, error) {
+	steps := m.meta.Bounds.Steps()
+	if cap(m.vals) < steps {
+		if m.vals != nil {
+			releaseValues(m.vals)
+		}
+		m.vals = acquireValues(steps)
+	}
+	vals := m.vals[:steps]
+
+	iter := m.seriesIters[m.index]
+	for i := 0; i < steps; i++ {
+		if !iter.Next() {
+			for ; i < steps; i++ {
+				vals[i] = math.NaN()
+			}
+			break
+		}
+		vals[i] = iter.Current()
+	}
+
+	meta := block.SeriesMeta{
+		Name: m.blocks[m.index].Metadata.Tags[models.MetricName],
+		Tags: m.blocks[m.index].Metadata.Tags,
+	}
+	return block.NewSeries(vals, meta), nil
+}
+
+// Close returns the series iter's value slab to the pool. The underlying
+// m3db SeriesIterators are owned by the MultiSeriesBlock they were created
+// from, not by this iterator, and are closed by MultiSeriesBlock.Close
+// instead; closing them here too would double-close a pool-backed resource
+// shared with any other iterator still reading the same block.
+func (m *multiSeriesBlockSeriesIter) Close() {
+	if m.vals != nil {
+		releaseValues(m.vals)
+		m.vals = nil
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package block
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3db/src/coordinator/block"
+)
+
+// fakeValueIterator replays a fixed slice of values, mirroring the contract
+// multiSeriesBlockStepIter/multiSeriesBlockSeriesIter expect from the
+// m3db-backed block.ValueIterator implementations.
+type fakeValueIterator struct {
+	vals []float64
+	idx  int
+}
+
+func newFakeValueIterator(vals []float64) *fakeValueIterator {
+	return &fakeValueIterator{vals: vals, idx: -1}
+}
+
+func (f *fakeValueIterator) Next() bool {
+	f.idx++
+	return f.idx < len(f.vals)
+}
+
+func (f *fakeValueIterator) Current() float64 {
+	return f.vals[f.idx]
+}
+
+// TestMultiSeriesBlockStepIterSeriesIterAgree fuzzes a handful of random
+// value matrices and checks that walking a MultiSeriesBlock vertically via
+// StepIter (one step across all series at a time) produces the exact same
+// values as walking it horizontally via SeriesIter (one full series at a
+// time), and that closing either iterator doesn't double-close the
+// underlying m3db SeriesIterators that MultiSeriesBlock.Close owns.
+func TestMultiSeriesBlockStepIterSeriesIterAgree(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+
+	for trial := 0; trial < 20; trial++ {
+		numSeries := 1 + rnd.Intn(5)
+		numSteps := 1 + rnd.Intn(20)
+
+		matrix := make([][]float64, numSeries)
+		for i := range matrix {
+			matrix[i] = make([]float64, numSteps)
+			for j := range matrix[i] {
+				matrix[i][j] = rnd.Float64()
+			}
+		}
+
+		bounds := block.Bounds{
+			Start:    time.Unix(0, 0),
+			End:      time.Unix(0, 0).Add(time.Duration(numSteps) * time.Minute),
+			StepSize: time.Minute,
+		}
+		meta := block.Metadata{Bounds: bounds}
+
+		stepIter := &multiSeriesBlockStepIter{
+			seriesIters: newFakeValueIterators(matrix),
+			index:       -1,
+			meta:        meta,
+		}
+		defer stepIter.Close()
+
+		stepMatrix := make([][]float64, numSeries)
+		for i := range stepMatrix {
+			stepMatrix[i] = make([]float64, 0, numSteps)
+		}
+		for stepIter.Next() {
+			step, err := stepIter.Current()
+			if err != nil {
+				t.Fatalf("trial %d: step iter Current: %v", trial, err)
+			}
+			for i, v := range step.Values() {
+				stepMatrix[i] = append(stepMatrix[i], v)
+			}
+		}
+
+		seriesIter := &multiSeriesBlockSeriesIter{
+			seriesIters: newFakeValueIterators(matrix),
+			index:       -1,
+			meta:        meta,
+		}
+		defer seriesIter.Close()
+
+		seriesMatrix := make([][]float64, 0, numSeries)
+		for seriesIter.Next() {
+			series, err := seriesIter.Current()
+			if err != nil {
+				t.Fatalf("trial %d: series iter Current: %v", trial, err)
+			}
+			seriesMatrix = append(seriesMatrix, append([]float64(nil), series.Values()...))
+		}
+
+		if len(stepMatrix) != len(seriesMatrix) {
+			t.Fatalf("trial %d: step iter produced %d series, series iter produced %d", trial, len(stepMatrix), len(seriesMatrix))
+		}
+		for i := range stepMatrix {
+			if len(stepMatrix[i]) != len(seriesMatrix[i]) {
+				t.Fatalf("trial %d: series %d: step iter produced %d values, series iter produced %d", trial, i, len(stepMatrix[i]), len(seriesMatrix[i]))
+			}
+			for j := range stepMatrix[i] {
+				if stepMatrix[i][j] != seriesMatrix[i][j] {
+					t.Fatalf("trial %d: series %d step %d: step iter got %v, series iter got %v", trial, i, j, stepMatrix[i][j], seriesMatrix[i][j])
+				}
+			}
+		}
+	}
+}
+
+func newFakeValueIterators(matrix [][]float64) []block.ValueIterator {
+	iters := make([]block.ValueIterator, len(matrix))
+	for i, vals := range matrix {
+		iters[i] = newFakeValueIterator(append([]float64(nil), vals...))
+	}
+	return iters
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import "time"
+
+const (
+	defaultMaxInFlightBatches = 16
+	defaultCommitInterval     = 10 * time.Second
+	defaultBlockSize          = 2 * time.Hour
+)
+
+// ClientType selects the underlying Kafka client library a ConsumerGroup
+// uses.
+type ClientType string
+
+// Supported client types.
+const (
+	// SaramaClientType uses github.com/Shopify/sarama's consumer group.
+	SaramaClientType ClientType = "sarama"
+	// KafkaGoClientType uses github.com/segmentio/kafka-go's consumer group.
+	KafkaGoClientType ClientType = "kafka-go"
+)
+
+// Configuration is the kafka ingestion source configuration, registered
+// under ingest.kafka in the dbnode YAML config.
+type Configuration struct {
+	// Brokers is the list of kafka broker addresses to bootstrap from.
+	Brokers []string `yaml:"brokers" validate:"nonzero"`
+
+	// ConsumerGroup is the consumer group ID partition assignment and offset
+	// checkpoints are tracked under.
+	ConsumerGroup string `yaml:"consumerGroup" validate:"nonzero"`
+
+	// Topics is the list of topics to consume.
+	Topics []string `yaml:"topics" validate:"nonzero"`
+
+	// Client selects the underlying consumer client implementation.
+	Client ClientType `yaml:"client"`
+
+	// Decoder selects how message payloads are decoded into datapoints.
+	Decoder DecoderType `yaml:"decoder"`
+
+	// MaxInFlightBatches bounds the number of decoded batches a partition
+	// worker will buffer ahead of the encoder, providing backpressure into
+	// the consumer when the encode/flush path falls behind.
+	MaxInFlightBatches int `yaml:"maxInFlightBatches"`
+
+	// CommitInterval is how often a partition worker checkpoints its last
+	// handled offset.
+	CommitInterval time.Duration `yaml:"commitInterval"`
+
+	// BlockSize is the size of the m3db block a partition worker aligns its
+	// per-series encoders to; an encoder is flushed and reset whenever a
+	// datapoint's timestamp crosses into the next block.
+	BlockSize time.Duration `yaml:"blockSize"`
+}
+
+// ClientOrDefault returns the configured client type, defaulting to sarama.
+func (c Configuration) ClientOrDefault() ClientType {
+	if c.Client == "" {
+		return SaramaClientType
+	}
+	return c.Client
+}
+
+// MaxInFlightBatchesOrDefault returns the configured in-flight batch limit,
+// or a sane default if unset.
+func (c Configuration) MaxInFlightBatchesOrDefault() int {
+	if c.MaxInFlightBatches <= 0 {
+		return defaultMaxInFlightBatches
+	}
+	return c.MaxInFlightBatches
+}
+
+// CommitIntervalOrDefault returns the configured commit interval, or a sane
+// default if unset.
+func (c Configuration) CommitIntervalOrDefault() time.Duration {
+	if c.CommitInterval <= 0 {
+		return defaultCommitInterval
+	}
+	return c.CommitInterval
+}
+
+// BlockSizeOrDefault returns the configured block size, or a sane default
+// if unset.
+func (c Configuration) BlockSizeOrDefault() time.Duration {
+	if c.BlockSize <= 0 {
+		return defaultBlockSize
+	}
+	return c.BlockSize
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+)
+
+// Message is a single consumed Kafka record.
+type Message struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Value     []byte
+}
+
+// PartitionConsumer streams messages for a single partition and checkpoints
+// offsets as the owning PartitionWorker flushes.
+type PartitionConsumer interface {
+	// Messages returns the channel new messages are delivered on. It is
+	// closed once the partition is revoked or the consumer is closed.
+	Messages() <-chan Message
+	// CommitOffset checkpoints offset as the last durably flushed offset for
+	// this partition.
+	CommitOffset(offset int64) error
+	// Lag reports the difference between the partition's high watermark and
+	// the last offset this consumer has read.
+	Lag() int64
+	// Close stops consuming and releases any underlying resources.
+	Close() error
+}
+
+// ConsumerGroup owns partition assignment for Configuration.Topics under
+// Configuration.ConsumerGroup and hands off a PartitionConsumer per assigned
+// partition.
+type ConsumerGroup interface {
+	// Partitions returns newly assigned PartitionConsumers as rebalances
+	// occur; callers should range over this channel for the lifetime of the
+	// consumer group.
+	Partitions() <-chan PartitionConsumer
+	// Close leaves the consumer group and stops all partition consumers.
+	Close() error
+}
+
+// NewConsumerGroup constructs the ConsumerGroup for cfg's configured client
+// type.
+func NewConsumerGroup(ctx context.Context, cfg Configuration) (ConsumerGroup, error) {
+	switch cfg.ClientOrDefault() {
+	case SaramaClientType:
+		return newSaramaConsumerGroup(ctx, cfg)
+	case KafkaGoClientType:
+		return newKafkaGoConsumerGroup(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("kafka: unknown client type %q", cfg.Client)
+	}
+}
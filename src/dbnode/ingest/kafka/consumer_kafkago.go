@@ -0,0 +1,234 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// kafkaGoConsumerGroup manually assigns partitions rather than relying on
+// kafka-go's GroupID-aware Reader: that Reader transparently multiplexes
+// every partition it owns through a single channel, which would leave one
+// PartitionWorker tracking a single offset sequence across several
+// partitions' independent ones. Instead, each topic's partitions are
+// discovered up front and given their own Reader pinned to a single
+// partition, and offsets are checkpointed through the consumer group's
+// broker-side storage directly, matching one PartitionConsumer per actual
+// Kafka partition, the same as the sarama client.
+type kafkaGoConsumerGroup struct {
+	client     *kafkago.Client
+	readers    []*kafkago.Reader
+	partitions chan PartitionConsumer
+	cancel     context.CancelFunc
+}
+
+func newKafkaGoConsumerGroup(ctx context.Context, cfg Configuration) (ConsumerGroup, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka: no brokers configured")
+	}
+
+	client := &kafkago.Client{Addr: kafkago.TCP(cfg.Brokers...)}
+
+	// Discover every topic's partitions up front so partitions can be sized
+	// to the true total: a topic commonly has more than one partition, and
+	// sending into an undersized buffered channel before anyone ranges over
+	// Partitions() would block construction forever.
+	topicPartitions := make(map[string][]int, len(cfg.Topics))
+	total := 0
+	for _, topic := range cfg.Topics {
+		partitions, err := discoverPartitions(cfg.Brokers[0], topic)
+		if err != nil {
+			return nil, err
+		}
+		topicPartitions[topic] = partitions
+		total += len(partitions)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	c := &kafkaGoConsumerGroup{
+		client:     client,
+		partitions: make(chan PartitionConsumer, total),
+		cancel:     cancel,
+	}
+
+	maxInFlight := cfg.MaxInFlightBatchesOrDefault()
+	for _, topic := range cfg.Topics {
+		for _, partition := range topicPartitions[topic] {
+			offset, err := fetchCommittedOffset(runCtx, client, cfg.ConsumerGroup, topic, partition)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+
+			reader := kafkago.NewReader(kafkago.ReaderConfig{
+				Brokers:   cfg.Brokers,
+				Topic:     topic,
+				Partition: partition,
+			})
+			if err := reader.SetOffset(offset); err != nil {
+				cancel()
+				return nil, err
+			}
+
+			c.readers = append(c.readers, reader)
+			c.partitions <- newKafkaGoPartitionConsumer(runCtx, client, cfg.ConsumerGroup, reader, topic, partition, maxInFlight)
+		}
+	}
+
+	return c, nil
+}
+
+// discoverPartitions returns the partition IDs of topic.
+func discoverPartitions(broker, topic string) ([]int, error) {
+	conn, err := kafkago.Dial("tcp", broker)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(partitions))
+	for i, p := range partitions {
+		ids[i] = p.ID
+	}
+	return ids, nil
+}
+
+// fetchCommittedOffset returns group's last committed offset for
+// (topic, partition), or kafkago.FirstOffset if the group has never
+// committed one.
+func fetchCommittedOffset(ctx context.Context, client *kafkago.Client, group, topic string, partition int) (int64, error) {
+	resp, err := client.OffsetFetch(ctx, &kafkago.OffsetFetchRequest{
+		GroupID: group,
+		Topics:  map[string][]int{topic: {partition}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("kafka: fetching committed offset for %s/%d: %v", topic, partition, err)
+	}
+
+	for _, p := range resp.Topics[topic] {
+		if p.Partition == partition && p.CommittedOffset >= 0 {
+			return p.CommittedOffset, nil
+		}
+	}
+	return kafkago.FirstOffset, nil
+}
+
+// Partitions returns newly assigned PartitionConsumers
+func (c *kafkaGoConsumerGroup) Partitions() <-chan PartitionConsumer {
+	return c.partitions
+}
+
+// Close leaves the consumer group
+func (c *kafkaGoConsumerGroup) Close() error {
+	c.cancel()
+
+	var lastErr error
+	for _, r := range c.readers {
+		if err := r.Close(); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+type kafkaGoPartitionConsumer struct {
+	client    *kafkago.Client
+	group     string
+	reader    *kafkago.Reader
+	topic     string
+	partition int
+	messages  chan Message
+}
+
+// newKafkaGoPartitionConsumer constructs a kafkaGoPartitionConsumer whose
+// Messages channel buffers up to maxInFlight fetched messages ahead of the
+// worker, so the fetch loop blocks on a full channel rather than growing it
+// unbounded when the encode/flush path falls behind.
+func newKafkaGoPartitionConsumer(ctx context.Context, client *kafkago.Client, group string, reader *kafkago.Reader, topic string, partition int, maxInFlight int) *kafkaGoPartitionConsumer {
+	p := &kafkaGoPartitionConsumer{
+		client:    client,
+		group:     group,
+		reader:    reader,
+		topic:     topic,
+		partition: partition,
+		messages:  make(chan Message, maxInFlight),
+	}
+	go p.run(ctx)
+	return p
+}
+
+func (p *kafkaGoPartitionConsumer) run(ctx context.Context) {
+	defer close(p.messages)
+	for {
+		msg, err := p.reader.FetchMessage(ctx)
+		if err != nil {
+			return
+		}
+		p.messages <- Message{
+			Topic:     msg.Topic,
+			Partition: int32(msg.Partition),
+			Offset:    msg.Offset,
+			Value:     msg.Value,
+		}
+	}
+}
+
+// Messages returns the channel new messages are delivered on
+func (p *kafkaGoPartitionConsumer) Messages() <-chan Message {
+	return p.messages
+}
+
+// Lag reports the partition's current consumer lag as tracked by the
+// underlying Reader.
+func (p *kafkaGoPartitionConsumer) Lag() int64 {
+	return p.reader.Stats().Lag
+}
+
+// CommitOffset checkpoints offset for this partition. It commits directly
+// through the consumer group's broker-side offset storage rather than
+// reader.CommitMessages, since the reader here is pinned to an explicit
+// partition rather than joined to the group (kafka-go doesn't allow
+// combining GroupID with an explicit Partition on a Reader). The committed
+// value is offset+1, matching the broker convention sarama's MarkOffset also
+// follows: a committed offset names the next offset to read, not the last
+// one consumed.
+func (p *kafkaGoPartitionConsumer) CommitOffset(offset int64) error {
+	_, err := p.client.OffsetCommit(context.Background(), &kafkago.OffsetCommitRequest{
+		GroupID: p.group,
+		Topics: map[string][]kafkago.OffsetCommit{
+			p.topic: {{Partition: p.partition, Offset: offset + 1}},
+		},
+	})
+	return err
+}
+
+// Close stops the background fetch loop and closes the reader
+func (p *kafkaGoPartitionConsumer) Close() error {
+	return p.reader.Close()
+}
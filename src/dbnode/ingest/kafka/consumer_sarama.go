@@ -0,0 +1,161 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"context"
+
+	"github.com/Shopify/sarama"
+)
+
+// saramaConsumerGroup bridges sarama's ConsumerGroup onto our ConsumerGroup
+// interface.
+type saramaConsumerGroup struct {
+	group       sarama.ConsumerGroup
+	topics      []string
+	partitions  chan PartitionConsumer
+	maxInFlight int
+	cancel      context.CancelFunc
+}
+
+func newSaramaConsumerGroup(ctx context.Context, cfg Configuration) (ConsumerGroup, error) {
+	saramaCfg := sarama.NewConfig()
+	saramaCfg.Consumer.Offsets.AutoCommit.Enable = false
+	saramaCfg.Version = sarama.V2_1_0_0
+
+	group, err := sarama.NewConsumerGroup(cfg.Brokers, cfg.ConsumerGroup, saramaCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	c := &saramaConsumerGroup{
+		group:       group,
+		topics:      cfg.Topics,
+		partitions:  make(chan PartitionConsumer),
+		maxInFlight: cfg.MaxInFlightBatchesOrDefault(),
+		cancel:      cancel,
+	}
+
+	go c.run(runCtx)
+	return c, nil
+}
+
+func (c *saramaConsumerGroup) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		// Consume returns whenever a rebalance happens; the session and its
+		// claims are gone once it does, so we just loop back into it until
+		// the context is canceled.
+		handler := &saramaHandler{partitions: c.partitions, maxInFlight: c.maxInFlight}
+		if err := c.group.Consume(ctx, c.topics, handler); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
+
+// Partitions returns newly assigned PartitionConsumers
+func (c *saramaConsumerGroup) Partitions() <-chan PartitionConsumer {
+	return c.partitions
+}
+
+// Close leaves the consumer group
+func (c *saramaConsumerGroup) Close() error {
+	c.cancel()
+	return c.group.Close()
+}
+
+// saramaHandler bridges sarama's claim-per-goroutine model onto our
+// PartitionConsumer interface.
+type saramaHandler struct {
+	partitions  chan<- PartitionConsumer
+	maxInFlight int
+}
+
+func (h *saramaHandler) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (h *saramaHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (h *saramaHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	pc := newSaramaPartitionConsumer(sess, claim, h.maxInFlight)
+	h.partitions <- pc
+
+	for msg := range claim.Messages() {
+		pc.deliver(Message{
+			Topic:     msg.Topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+			Value:     msg.Value,
+		})
+	}
+	close(pc.messages)
+	return nil
+}
+
+type saramaPartitionConsumer struct {
+	sess     sarama.ConsumerGroupSession
+	claim    sarama.ConsumerGroupClaim
+	messages chan Message
+
+	lastOffset int64
+}
+
+// newSaramaPartitionConsumer constructs a saramaPartitionConsumer whose
+// Messages channel buffers up to maxInFlight decoded batches ahead of the
+// worker, so the claim's fetch loop blocks on a full channel rather than
+// growing it unbounded when the encode/flush path falls behind.
+func newSaramaPartitionConsumer(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, maxInFlight int) *saramaPartitionConsumer {
+	return &saramaPartitionConsumer{
+		sess:       sess,
+		claim:      claim,
+		messages:   make(chan Message, maxInFlight),
+		lastOffset: -1,
+	}
+}
+
+func (p *saramaPartitionConsumer) deliver(msg Message) {
+	p.lastOffset = msg.Offset
+	p.messages <- msg
+}
+
+// Lag reports the difference between the partition's high watermark and the
+// last message delivered to this consumer.
+func (p *saramaPartitionConsumer) Lag() int64 {
+	return p.claim.HighWaterMarkOffset() - p.lastOffset - 1
+}
+
+// Messages returns the channel new messages are delivered on
+func (p *saramaPartitionConsumer) Messages() <-chan Message {
+	return p.messages
+}
+
+// CommitOffset checkpoints offset for this partition. MarkOffset only marks
+// the session's in-memory high-water mark; with AutoCommit disabled
+// (newSaramaConsumerGroup), Commit must be called explicitly to actually
+// flush it to the broker.
+func (p *saramaPartitionConsumer) CommitOffset(offset int64) error {
+	p.sess.MarkOffset(p.claim.Topic(), p.claim.Partition(), offset+1, "")
+	p.sess.Commit()
+	return nil
+}
+
+// Close is a no-op; the claim is torn down by ConsumeClaim returning
+func (p *saramaPartitionConsumer) Close() error { return nil }
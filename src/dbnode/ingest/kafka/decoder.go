@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"fmt"
+	"time"
+)
+
+// Datapoint is a single decoded sample, ready to be appended onto its
+// series' tsz encoder.
+type Datapoint struct {
+	SeriesID   string
+	Timestamp  time.Time
+	Value      float64
+	Annotation []byte
+}
+
+// Decoder turns a raw Kafka message payload into zero or more Datapoints.
+// Implementations are reused across messages on the same partition worker
+// but are not required to be safe for concurrent use.
+type Decoder interface {
+	Decode(msg []byte) ([]Datapoint, error)
+}
+
+// DecoderType selects which Decoder a partition worker uses.
+type DecoderType string
+
+// Supported decoder types.
+const (
+	// PrometheusRemoteWriteDecoderType decodes the protobuf envelope used by
+	// Prometheus' remote_write feature.
+	PrometheusRemoteWriteDecoderType DecoderType = "prometheus_remote_write"
+	// InfluxLineProtocolDecoderType decodes InfluxDB line protocol.
+	InfluxLineProtocolDecoderType DecoderType = "influx_line_protocol"
+	// CompactDecoderType decodes the compact (seriesID, timestamp, value,
+	// annotation) framing described on compactDecoder. It is the default.
+	CompactDecoderType DecoderType = "compact"
+)
+
+// NewDecoder constructs the Decoder for the given type.
+func NewDecoder(t DecoderType) (Decoder, error) {
+	switch t {
+	case PrometheusRemoteWriteDecoderType:
+		return newPrometheusRemoteWriteDecoder(), nil
+	case InfluxLineProtocolDecoderType:
+		return newInfluxLineProtocolDecoder(), nil
+	case CompactDecoderType, "":
+		return newCompactDecoder(), nil
+	default:
+		return nil, fmt.Errorf("kafka: unknown decoder type %q", t)
+	}
+}
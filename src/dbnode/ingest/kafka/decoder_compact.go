@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// compactDecoder decodes a minimal framing for producers that want to avoid
+// a full serialization framework.
+//
+// Wire format (big endian): uint16 seriesID length, seriesID bytes,
+// int64 timestamp (unix nanos), float64 value, uint16 annotation length,
+// annotation bytes.
+type compactDecoder struct{}
+
+func newCompactDecoder() *compactDecoder {
+	return &compactDecoder{}
+}
+
+func (d *compactDecoder) Decode(msg []byte) ([]Datapoint, error) {
+	buf := msg
+	if len(buf) < 2 {
+		return nil, fmt.Errorf("kafka: compact frame too short (%d bytes)", len(msg))
+	}
+
+	seriesIDLen := int(binary.BigEndian.Uint16(buf[0:2]))
+	buf = buf[2:]
+	if len(buf) < seriesIDLen+8+8+2 {
+		return nil, fmt.Errorf("kafka: compact frame truncated")
+	}
+	seriesID := string(buf[:seriesIDLen])
+	buf = buf[seriesIDLen:]
+
+	tsNanos := int64(binary.BigEndian.Uint64(buf[:8]))
+	buf = buf[8:]
+	value := math.Float64frombits(binary.BigEndian.Uint64(buf[:8]))
+	buf = buf[8:]
+
+	antLen := int(binary.BigEndian.Uint16(buf[:2]))
+	buf = buf[2:]
+	if len(buf) < antLen {
+		return nil, fmt.Errorf("kafka: compact frame annotation truncated")
+	}
+
+	var ant []byte
+	if antLen > 0 {
+		ant = append([]byte(nil), buf[:antLen]...)
+	}
+
+	return []Datapoint{{
+		SeriesID:   seriesID,
+		Timestamp:  time.Unix(0, tsNanos),
+		Value:      value,
+		Annotation: ant,
+	}}, nil
+}
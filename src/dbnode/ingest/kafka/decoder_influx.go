@@ -0,0 +1,75 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// influxLineProtocolDecoder decodes InfluxDB line protocol payloads
+// (measurement,tag=val field=val timestamp), accepting either one line per
+// Kafka message or a newline-delimited batch of lines in one message.
+type influxLineProtocolDecoder struct{}
+
+func newInfluxLineProtocolDecoder() *influxLineProtocolDecoder {
+	return &influxLineProtocolDecoder{}
+}
+
+func (d *influxLineProtocolDecoder) Decode(msg []byte) ([]Datapoint, error) {
+	var points []Datapoint
+	for _, line := range strings.Split(string(msg), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("kafka: malformed influx line %q", line)
+		}
+		measurementAndTags, fieldSet, tsRaw := fields[0], fields[1], fields[2]
+
+		ts, err := strconv.ParseInt(tsRaw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: malformed influx timestamp %q: %v", tsRaw, err)
+		}
+
+		for _, kv := range strings.Split(fieldSet, ",") {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			val, err := strconv.ParseFloat(parts[1], 64)
+			if err != nil {
+				continue
+			}
+			points = append(points, Datapoint{
+				SeriesID:  measurementAndTags + "#" + parts[0],
+				Timestamp: time.Unix(0, ts),
+				Value:     val,
+			})
+		}
+	}
+	return points, nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// prometheusRemoteWriteDecoder decodes the protobuf envelope used by
+// Prometheus' remote_write feature into per-sample Datapoints, keying each
+// series on its serialized label set.
+type prometheusRemoteWriteDecoder struct{}
+
+func newPrometheusRemoteWriteDecoder() *prometheusRemoteWriteDecoder {
+	return &prometheusRemoteWriteDecoder{}
+}
+
+func (d *prometheusRemoteWriteDecoder) Decode(msg []byte) ([]Datapoint, error) {
+	var req prompb.WriteRequest
+	if err := req.Unmarshal(msg); err != nil {
+		return nil, err
+	}
+
+	var points []Datapoint
+	for _, ts := range req.Timeseries {
+		id := seriesIDFromLabels(ts.Labels)
+		for _, s := range ts.Samples {
+			points = append(points, Datapoint{
+				SeriesID:  id,
+				Timestamp: time.Unix(0, s.Timestamp*int64(time.Millisecond)),
+				Value:     s.Value,
+			})
+		}
+	}
+	return points, nil
+}
+
+func seriesIDFromLabels(labels []prompb.Label) string {
+	id := ""
+	for _, l := range labels {
+		id += l.Name + "=" + l.Value + ","
+	}
+	return id
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package kafka consumes datapoints from Kafka and feeds them into the same
+// tsz encoding pipeline the write path uses, so operators can size an
+// ingest tier independently of the query tier. See Configuration for the
+// ingest.kafka YAML block.
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/m3db/m3db/encoding/tsz"
+	"github.com/uber-go/tally"
+)
+
+// Source owns a ConsumerGroup and a PartitionWorker per partition assigned
+// to this process for the lifetime of a Run call.
+type Source struct {
+	cfg     Configuration
+	encOpts tsz.Options
+	flusher Flusher
+	metrics Metrics
+}
+
+// NewSource constructs a Source from cfg. Call Run to start consuming.
+func NewSource(cfg Configuration, encOpts tsz.Options, flusher Flusher, scope tally.Scope) *Source {
+	return &Source{
+		cfg:     cfg,
+		encOpts: encOpts,
+		flusher: flusher,
+		metrics: NewMetrics(scope),
+	}
+}
+
+// Run blocks, consuming every partition assigned to this process until ctx
+// is canceled or the consumer group is lost. A single partition worker
+// dying is treated as fatal to the whole Source rather than leaking its
+// consumer's background fetch goroutine silently: Run returns the wrapped
+// error, and its deferred group.Close() tears down every other partition's
+// worker along with it.
+func (s *Source) Run(ctx context.Context) error {
+	group, err := NewConsumerGroup(ctx, s.cfg)
+	if err != nil {
+		return err
+	}
+	defer group.Close()
+
+	errCh := make(chan error, 1)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			return err
+		case pc, ok := <-group.Partitions():
+			if !ok {
+				return nil
+			}
+
+			worker, err := NewPartitionWorker(pc, s.cfg, s.encOpts, s.flusher, s.metrics)
+			if err != nil {
+				return err
+			}
+
+			go s.runWorker(pc, worker, errCh)
+		}
+	}
+}
+
+// runWorker runs w until it returns, then closes pc so its consumer's
+// background fetch goroutine isn't left blocked sending on a channel
+// nothing reads anymore, and reports the failure back to Run via errCh.
+func (s *Source) runWorker(pc PartitionConsumer, w *PartitionWorker, errCh chan<- error) {
+	if err := w.Run(); err != nil {
+		s.metrics.workerErrors.Inc(1)
+		pc.Close()
+		errCh <- fmt.Errorf("kafka: partition worker stopped: %v", err)
+	}
+}
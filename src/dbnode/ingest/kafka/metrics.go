@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import "github.com/uber-go/tally"
+
+// Metrics are the per-ingestion-source metrics exposed so operators can size
+// the ingest tier independently of the query tier.
+type Metrics struct {
+	consumerLag  tally.Gauge
+	decodeErrors tally.Counter
+	flushErrors  tally.Counter
+	workerErrors tally.Counter
+}
+
+// NewMetrics constructs Metrics reporting under scope.
+func NewMetrics(scope tally.Scope) Metrics {
+	scope = scope.SubScope("ingest").SubScope("kafka")
+	return Metrics{
+		consumerLag:  scope.Gauge("consumer-lag"),
+		decodeErrors: scope.Counter("decode-errors"),
+		flushErrors:  scope.Counter("flush-errors"),
+		workerErrors: scope.Counter("worker-errors"),
+	}
+}
+
+// RecordLag reports the most recently observed partition lag, i.e. the
+// difference between the partition's high watermark and the last committed
+// offset.
+func (m Metrics) RecordLag(lag int64) {
+	m.consumerLag.Update(float64(lag))
+}
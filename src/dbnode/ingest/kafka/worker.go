@@ -0,0 +1,240 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3db/encoding/tsz"
+	"github.com/m3db/m3db/interfaces/m3db"
+	xtime "github.com/m3db/m3db/x/time"
+)
+
+// encoderPool recycles per-series tsz encoders so a partition worker does
+// not allocate a fresh encoder for every series it touches across block
+// boundaries, mirroring the reader side's ReaderIteratorPool.
+type encoderPool struct {
+	pool sync.Pool
+}
+
+func newEncoderPool(opts tsz.Options) *encoderPool {
+	p := &encoderPool{}
+	p.pool.New = func() interface{} {
+		return tsz.NewEncoder(time.Time{}, nil, opts)
+	}
+	return p
+}
+
+func (p *encoderPool) Get() m3db.Encoder {
+	return p.pool.Get().(m3db.Encoder)
+}
+
+func (p *encoderPool) Put(enc m3db.Encoder) {
+	enc.Reset(time.Time{}, 0)
+	p.pool.Put(enc)
+}
+
+// Flusher durably persists a series' encoded block once its boundary is
+// reached. A PartitionWorker only checkpoints an offset once every series
+// touched up to that offset has been handed to Flusher, so implementations
+// must persist the block and acknowledge it atomically to preserve
+// exactly-once semantics.
+type Flusher interface {
+	Flush(seriesID string, enc m3db.Encoder, blockStart time.Time) error
+}
+
+// PartitionWorker owns decoding and encoding for a single assigned
+// partition: it drains messages from its PartitionConsumer, decodes each
+// into Datapoints, appends them onto a per-series tsz encoder exactly the
+// way the write path does, flushing and rolling each encoder over whenever a
+// datapoint crosses into the next block. It only checkpoints the partition's
+// offset, on commitInterval, up to the last message whose block has already
+// been durably flushed, so a restart never resumes past unflushed data.
+type PartitionWorker struct {
+	consumer PartitionConsumer
+	decoder  Decoder
+	encoders *encoderPool
+	flusher  Flusher
+	metrics  Metrics
+
+	mu         sync.Mutex
+	series     map[string]m3db.Encoder
+	blockStart time.Time
+
+	commitInterval time.Duration
+	blockSize      time.Duration
+
+	lastSeenOffset    int64
+	lastFlushedOffset int64
+}
+
+// NewPartitionWorker constructs a PartitionWorker for a single assigned
+// partition using cfg's decoder, block size and commit interval.
+func NewPartitionWorker(consumer PartitionConsumer, cfg Configuration, encOpts tsz.Options, flusher Flusher, metrics Metrics) (*PartitionWorker, error) {
+	decoder, err := NewDecoder(cfg.Decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PartitionWorker{
+		consumer:          consumer,
+		decoder:           decoder,
+		encoders:          newEncoderPool(encOpts),
+		flusher:           flusher,
+		metrics:           metrics,
+		series:            make(map[string]m3db.Encoder),
+		commitInterval:    cfg.CommitIntervalOrDefault(),
+		blockSize:         cfg.BlockSizeOrDefault(),
+		lastSeenOffset:    -1,
+		lastFlushedOffset: -1,
+	}, nil
+}
+
+// Run drains the partition's messages until the PartitionConsumer closes its
+// Messages channel, checkpointing the last flushed offset every
+// commitInterval, and flushes and commits whatever remains once the channel
+// closes.
+func (w *PartitionWorker) Run() error {
+	ticker := time.NewTicker(w.commitInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-w.consumer.Messages():
+			if !ok {
+				if err := w.flushCurrentBlock(); err != nil {
+					return err
+				}
+				return w.commitFlushedOffset()
+			}
+			if err := w.handle(msg); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			w.metrics.RecordLag(w.consumer.Lag())
+			if err := w.commitFlushedOffset(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (w *PartitionWorker) handle(msg Message) error {
+	points, err := w.decoder.Decode(msg.Value)
+	if err != nil {
+		w.metrics.decodeErrors.Inc(1)
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, p := range points {
+		blockStart := p.Timestamp.Truncate(w.blockSize)
+
+		switch {
+		case w.blockStart.IsZero():
+			w.blockStart = blockStart
+		case blockStart.After(w.blockStart):
+			// A point crossed into the next block: flush everything seen so
+			// far before encoding into the new one, and only now is it safe
+			// to advance the flushed offset up to the prior message (this
+			// message's own points are encoded below, so its offset isn't
+			// flushed yet).
+			if err := w.flushLocked(w.blockStart); err != nil {
+				return err
+			}
+			w.lastFlushedOffset = w.lastSeenOffset
+			w.blockStart = blockStart
+		case blockStart.Before(w.blockStart):
+			// A point arrived for a block that's already been rolled past
+			// (and possibly already flushed): encoding it onto the current
+			// block's encoder would violate tsz's monotonically increasing
+			// timestamp requirement and corrupt the block. There's no open
+			// encoder left for the earlier block to append it to instead, so
+			// surface it rather than silently drop or corrupt.
+			return fmt.Errorf("kafka: datapoint for series %s at %s arrived after its block %s was rolled past", p.SeriesID, p.Timestamp, blockStart)
+		}
+
+		enc, ok := w.series[p.SeriesID]
+		if !ok {
+			enc = w.encoders.Get()
+			enc.Reset(w.blockStart, 0)
+			w.series[p.SeriesID] = enc
+		}
+
+		dp := m3db.Datapoint{Timestamp: p.Timestamp, Value: p.Value}
+		if err := enc.Encode(dp, xtime.Nanosecond, m3db.Annotation(p.Annotation)); err != nil {
+			return err
+		}
+	}
+
+	w.lastSeenOffset = msg.Offset
+	return nil
+}
+
+// flushCurrentBlock flushes whatever block is currently open, e.g. once the
+// partition is being given up on a rebalance or shutdown.
+func (w *PartitionWorker) flushCurrentBlock() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.blockStart.IsZero() {
+		return nil
+	}
+	if err := w.flushLocked(w.blockStart); err != nil {
+		return err
+	}
+	w.lastFlushedOffset = w.lastSeenOffset
+	return nil
+}
+
+// commitFlushedOffset checkpoints the consumer's offset up to
+// lastFlushedOffset, the highest offset whose block has actually been
+// handed to Flusher; it never commits past that, so a crash before a block
+// is flushed replays from the start of that block instead of skipping it.
+func (w *PartitionWorker) commitFlushedOffset() error {
+	w.mu.Lock()
+	offset := w.lastFlushedOffset
+	w.mu.Unlock()
+
+	if offset < 0 {
+		return nil
+	}
+	return w.consumer.CommitOffset(offset)
+}
+
+// flushLocked hands every tracked series' encoder to Flusher keyed at
+// blockStart, then returns each encoder to the pool for reuse by the next
+// block. Callers must hold w.mu.
+func (w *PartitionWorker) flushLocked(blockStart time.Time) error {
+	for seriesID, enc := range w.series {
+		if err := w.flusher.Flush(seriesID, enc, blockStart); err != nil {
+			w.metrics.flushErrors.Inc(1)
+			return err
+		}
+		w.encoders.Put(enc)
+		delete(w.series, seriesID)
+	}
+	return nil
+}
@@ -31,27 +31,93 @@ const (
 	// 	 n < 0 = -Inf
 	// 	 n > 1 = +Inf
 	QuantileType = "quantile"
+
+	// QuantileTDigestType is an alternative to QuantileType backed by a
+	// merging t-digest: rather than buffering every non-NaN value and
+	// sorting it (O(N log N) memory/CPU per bucket), it maintains a bounded
+	// set of weighted centroids, trading a small amount of accuracy for
+	// streaming, constant-memory aggregation over wide fan-in queries.
+	// Special cases match QuantileType.
+	QuantileTDigestType = "quantile_tdigest"
 )
 
+// Options configures the aggregation functions makeQuantileFn builds.
+type Options struct {
+	// UseTDigestForQuantile makes QuantileType itself resolve to the
+	// t-digest implementation rather than the exact, sort-based one.
+	// Operators with wide fan-in quantile queries can set this to trade a
+	// small amount of accuracy for bounded memory per bucket.
+	UseTDigestForQuantile bool
+}
+
 // Creates a quantile aggregation function for a given n-quantile measurement
-func makeQuantileFn(opType string, n float64) (aggregationFn, bool) {
-	if opType != QuantileType {
+func makeQuantileFn(opType string, n float64, opts Options) (aggregationFn, bool) {
+	switch opType {
+	case QuantileType:
+		if opts.UseTDigestForQuantile {
+			return makeQuantileTDigestFn(n), true
+		}
+		return func(values []float64, buckets []int) float64 {
+			return quantileFn(n, values, buckets)
+		}, true
+	case QuantileTDigestType:
+		return makeQuantileTDigestFn(n), true
+	default:
 		return nil, false
 	}
+}
+
+func makeQuantileTDigestFn(n float64) aggregationFn {
 	return func(values []float64, buckets []int) float64 {
-		return quantileFn(n, values, buckets)
-	}, true
+		return quantileTDigestFn(n, values, buckets)
+	}
 }
 
-func quantileFn(n float64, values []float64, buckets []int) float64 {
+// nonNaNQuantileInputOrSpecialCase reports the two cases common to both
+// quantile implementations: an empty input (NaN), and n outside [0, 1]
+// (the correctly signed infinity). ok is false when the caller still needs
+// to compute an actual quantile.
+func nonNaNQuantileInputOrSpecialCase(n float64, values []float64, buckets []int) (result float64, ok bool) {
 	if len(buckets) == 0 || len(values) == 0 {
-		return math.NaN()
+		return math.NaN(), true
 	}
 
 	if n < 0 || n > 1 {
 		// Use math.Inf(0) == +Inf by truncating n and subtracting 1 to give
 		// the correctly signed infinity
-		return math.Inf(int(n) - 1)
+		return math.Inf(int(n) - 1), true
+	}
+
+	return 0, false
+}
+
+func quantileTDigestFn(n float64, values []float64, buckets []int) float64 {
+	if result, ok := nonNaNQuantileInputOrSpecialCase(n, values, buckets); ok {
+		return result
+	}
+
+	digest := newTDigest(defaultTDigestCompression)
+	seen := false
+	for _, idx := range buckets {
+		val := values[idx]
+		if math.IsNaN(val) {
+			continue
+		}
+		digest.Insert(val)
+		seen = true
+	}
+
+	if !seen {
+		// No non-NaN values
+		return math.NaN()
+	}
+
+	return digest.Quantile(n)
+}
+
+func quantileFn(n float64, values []float64, buckets []int) float64 {
+	if result, ok := nonNaNQuantileInputOrSpecialCase(n, values, buckets); ok {
+		return result
 	}
 
 	bucketVals := make([]float64, 0, len(buckets))
@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregation
+
+import (
+	"math"
+	"sort"
+)
+
+// defaultTDigestCompression bounds how many centroids a tdigest grows to
+// hold; larger values trade memory/CPU for accuracy.
+const defaultTDigestCompression = 100
+
+// centroid is a single weighted mean maintained by a tdigest.
+type centroid struct {
+	mean  float64
+	count float64
+}
+
+// tdigest is a merging t-digest (Dunning & Ertl) that estimates a quantile
+// from a stream of values in a bounded number of centroids, rather than
+// buffering and sorting every value the way quantileFn does.
+type tdigest struct {
+	compression float64
+	centroids   []centroid
+	count       float64
+}
+
+// newTDigest constructs an empty tdigest with the given compression
+// parameter, defaulting to defaultTDigestCompression if non-positive.
+func newTDigest(compression float64) *tdigest {
+	if compression <= 0 {
+		compression = defaultTDigestCompression
+	}
+	return &tdigest{compression: compression}
+}
+
+// Insert adds x to the digest.
+func (t *tdigest) Insert(x float64) {
+	t.insertWeighted(x, 1)
+}
+
+// Merge combines other's centroids into t, so partial aggregations
+// computed independently (e.g. across shards) can be combined without
+// re-sending raw samples.
+func (t *tdigest) Merge(other *tdigest) {
+	if other == nil {
+		return
+	}
+	for _, c := range other.centroids {
+		t.insertWeighted(c.mean, c.count)
+	}
+}
+
+// insertWeighted merges in a sample (or an already-merged centroid) of the
+// given mean and weight: it finds the nearest existing centroid and, if
+// absorbing the weight keeps that centroid under the scale function's
+// limit, merges it in via the incremental mean update; otherwise the
+// sample becomes its own centroid.
+func (t *tdigest) insertWeighted(mean, weight float64) {
+	t.count += weight
+
+	if len(t.centroids) == 0 {
+		t.centroids = []centroid{{mean: mean, count: weight}}
+		return
+	}
+
+	idx := t.nearestIndex(mean)
+	if t.canMerge(idx, weight) {
+		c := &t.centroids[idx]
+		if c.mean != mean {
+			// Guard against Inf-Inf producing a NaN mean when merging two
+			// centroids that are both already at the same infinity.
+			c.mean += (mean - c.mean) * weight / (c.count + weight)
+		}
+		c.count += weight
+		return
+	}
+
+	t.insertCentroidAt(mean, weight)
+}
+
+// nearestIndex returns the index of the centroid whose mean is closest to
+// x, assuming centroids is sorted by mean.
+func (t *tdigest) nearestIndex(x float64) int {
+	i := sort.Search(len(t.centroids), func(i int) bool {
+		return t.centroids[i].mean >= x
+	})
+
+	switch {
+	case i == 0:
+		return 0
+	case i == len(t.centroids):
+		return i - 1
+	case x-t.centroids[i-1].mean <= t.centroids[i].mean-x:
+		return i - 1
+	default:
+		return i
+	}
+}
+
+// canMerge reports whether the centroid at idx can absorb weight more
+// without exceeding the t-digest scale function's limit:
+// 4 * totalWeight * q * (1-q) / compression, where q is that centroid's
+// normalized position within the digest.
+func (t *tdigest) canMerge(idx int, weight float64) bool {
+	before := t.weightBefore(idx)
+	c := t.centroids[idx]
+	q := (before + c.count/2) / t.count
+	limit := 4 * t.count * q * (1 - q) / t.compression
+	return c.count+weight <= limit
+}
+
+func (t *tdigest) weightBefore(idx int) float64 {
+	var w float64
+	for _, c := range t.centroids[:idx] {
+		w += c.count
+	}
+	return w
+}
+
+// insertCentroidAt inserts a new centroid, keeping centroids sorted by
+// mean.
+func (t *tdigest) insertCentroidAt(mean, weight float64) {
+	i := sort.Search(len(t.centroids), func(i int) bool {
+		return t.centroids[i].mean >= mean
+	})
+
+	t.centroids = append(t.centroids, centroid{})
+	copy(t.centroids[i+1:], t.centroids[i:])
+	t.centroids[i] = centroid{mean: mean, count: weight}
+}
+
+// Quantile returns the estimated value at quantile q (0 <= q <= 1),
+// walking the centroids' cumulative weight and linearly interpolating
+// between the two centroids straddling q * totalWeight.
+func (t *tdigest) Quantile(q float64) float64 {
+	n := len(t.centroids)
+	if n == 0 {
+		return math.NaN()
+	}
+	if n == 1 {
+		return t.centroids[0].mean
+	}
+
+	centers := make([]float64, n)
+	var cum float64
+	for i, c := range t.centroids {
+		centers[i] = cum + c.count/2
+		cum += c.count
+	}
+
+	target := q * t.count
+	if target <= centers[0] {
+		return t.centroids[0].mean
+	}
+	if target >= centers[n-1] {
+		return t.centroids[n-1].mean
+	}
+
+	i := sort.Search(n, func(i int) bool { return centers[i] >= target })
+	left, right := t.centroids[i-1], t.centroids[i]
+	weight := (target - centers[i-1]) / (centers[i] - centers[i-1])
+	return left.mean + weight*(right.mean-left.mean)
+}